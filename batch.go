@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hostTarget is one line parsed from a -hosts file.
+type hostTarget struct {
+	Host string
+	Port string
+}
+
+// parseHostsFile reads host[:port] targets, one per line, from path. Blank
+// lines and lines starting with # are ignored. Targets without an explicit
+// port use defaultPort. A bare IPv6 literal (no port) is taken as-is; to
+// give one an explicit port it must be bracketed, e.g. "[::1]:8443", same as
+// any other host:port pair.
+func parseHostsFile(path, defaultPort string) ([]hostTarget, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var targets []hostTarget
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		host, port := line, defaultPort
+		if h, p, err := net.SplitHostPort(line); err == nil {
+			host, port = h, p
+		}
+		targets = append(targets, hostTarget{Host: host, Port: port})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return targets, nil
+}
+
+// runBatch scans every target in hostsPath with a pool of concurrency
+// workers and prints each result as an independent, clearly delimited block
+// (a standalone JSON object in "json" format, a headed text block
+// otherwise). It returns the process exit code: non-zero if any target
+// failed to connect/verify, is expiring within minDays, or supports a
+// deprecated TLS version.
+func runBatch(hostsPath, defaultPort string, timeout time.Duration, verbose bool, format string, dial dialFunc, concurrency, minDays int) int {
+	targets, err := parseHostsFile(hostsPath, defaultPort)
+	if err != nil {
+		fmt.Printf("Error: failed to read -hosts file: %v\n", err)
+		return 1
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	jobs := make(chan hostTarget)
+	var wg sync.WaitGroup
+	var mu sync.Mutex // serializes stdout writes so each target's output stays a contiguous block
+	failed := false
+
+	worker := func() {
+		defer wg.Done()
+		for target := range jobs {
+			var buf bytes.Buffer
+			report, err := checkSSL(&buf, target.Host, target.Port, timeout, verbose, format, dial)
+			if err != nil {
+				report.Error = err.Error()
+			}
+
+			mu.Lock()
+			if format == "json" {
+				printJSONReport(os.Stdout, report)
+			} else {
+				fmt.Printf("=== %s:%s ===\n", target.Host, target.Port)
+				os.Stdout.Write(buf.Bytes())
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+				}
+				fmt.Println()
+			}
+			if err != nil || targetNeedsAttention(report, minDays) {
+				failed = true
+			}
+			mu.Unlock()
+		}
+	}
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go worker()
+	}
+	for _, target := range targets {
+		jobs <- target
+	}
+	close(jobs)
+	wg.Wait()
+
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// targetNeedsAttention reports whether report represents a target that
+// should fail a batch run: failed chain verification, a certificate
+// expiring within minDays, or support for a deprecated TLS version.
+func targetNeedsAttention(report *Report, minDays int) bool {
+	if report.ChainVerification != nil && !report.ChainVerification.Passed {
+		return true
+	}
+
+	for _, cert := range report.Chain {
+		if cert.DaysLeft < minDays {
+			return true
+		}
+	}
+
+	for _, v := range report.TLSVersions {
+		if v.Supported && strings.Contains(v.Version, "deprecated") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,14 +1,24 @@
 package main
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
+
+	"golang.org/x/crypto/ocsp"
 )
 
 // TLS protocol versions mapped to their names
@@ -20,77 +30,177 @@ var tlsVersions = map[uint16]string{
 	tls.VersionTLS13: "TLS 1.3",
 }
 
+// crlCache memoizes fetched and parsed CRLs by URL for the lifetime of the
+// process, since they can be large and a chain may reference the same
+// distribution point multiple times.
+var crlCache = struct {
+	mu      sync.Mutex
+	entries map[string]*x509.RevocationList
+}{entries: make(map[string]*x509.RevocationList)}
+
 func main() {
 	// Parse command line arguments
 	host := flag.String("host", "", "Host to check (e.g., example.com)")
+	hostsFile := flag.String("hosts", "", "File of host[:port] targets to scan concurrently, one per line (mutually exclusive with -host)")
+	concurrency := flag.Int("concurrency", 10, "Number of concurrent workers when scanning -hosts")
+	minDays := flag.Int("min-days", 14, "Flag certificates expiring within this many days when scanning -hosts")
 	port := flag.String("port", "443", "Port to connect to (default: 443)")
 	timeout := flag.Int("timeout", 10, "Connection timeout in seconds")
 	verbose := flag.Bool("verbose", false, "Show detailed certificate information")
+	format := flag.String("format", "text", "Output format: text or json")
+	starttls := flag.String("starttls", "", "Upgrade via STARTTLS before the TLS handshake: smtp, imap, pop3, ftp, ldap, or postgres")
 	flag.Parse()
 
-	if *host == "" {
-		fmt.Println("Error: Host is required")
-		fmt.Println("Usage: sslcheck -host example.com [-port 443] [-timeout 10] [-verbose]")
+	if *host == "" && *hostsFile == "" {
+		fmt.Println("Error: -host or -hosts is required")
+		fmt.Println("Usage: sslcheck -host example.com [-port 443] [-timeout 10] [-verbose] [-format text|json] [-starttls proto]")
+		fmt.Println("       sslcheck -hosts targets.txt [-concurrency 10] [-min-days 14] ...")
+		os.Exit(1)
+	}
+
+	if *host != "" && *hostsFile != "" {
+		fmt.Println("Error: -host and -hosts are mutually exclusive")
+		os.Exit(1)
+	}
+
+	if *format != "text" && *format != "json" {
+		fmt.Printf("Error: invalid -format %q, must be \"text\" or \"json\"\n", *format)
 		os.Exit(1)
 	}
 
+	dial := dialFunc(plainDial)
+	if *starttls != "" {
+		proto := strings.ToLower(*starttls)
+		d, ok := starttlsDialers[proto]
+		if !ok {
+			fmt.Printf("Error: unsupported -starttls protocol %q (want smtp, imap, pop3, ftp, ldap, or postgres)\n", *starttls)
+			os.Exit(1)
+		}
+		dial = d
+
+		portExplicit := false
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "port" {
+				portExplicit = true
+			}
+		})
+		if !portExplicit {
+			*port = starttlsDefaultPorts[proto]
+		}
+	}
+
+	timeoutDuration := time.Duration(*timeout) * time.Second
+
+	if *hostsFile != "" {
+		exitCode := runBatch(*hostsFile, *port, timeoutDuration, *verbose, *format, dial, *concurrency, *minDays)
+		os.Exit(exitCode)
+	}
+
 	// Run the SSL check
-	err := checkSSL(*host, *port, time.Duration(*timeout)*time.Second, *verbose)
+	report, err := checkSSL(os.Stdout, *host, *port, timeoutDuration, *verbose, *format, dial)
 	if err != nil {
-		fmt.Printf("Error: %v\n", err)
+		if *format == "json" {
+			report.Error = err.Error()
+			printJSONReport(os.Stdout, report)
+		} else {
+			fmt.Printf("Error: %v\n", err)
+		}
 		os.Exit(1)
 	}
+
+	if *format == "json" {
+		printJSONReport(os.Stdout, report)
+	}
 }
 
-func checkSSL(host, port string, timeout time.Duration, verbose bool) error {
-	fmt.Printf("Checking SSL/TLS for %s:%s\n\n", host, port)
+// printJSONReport marshals report as indented JSON and writes it to w.
+func printJSONReport(w io.Writer, report *Report) {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Fprintf(w, "Error: failed to marshal report: %v\n", err)
+		return
+	}
+	fmt.Fprintln(w, string(data))
+}
+
+// checkSSL runs every check against host:port and, in "text" format, prints
+// human-readable results as it goes. It always returns a populated Report so
+// callers can additionally emit it as JSON. dial is used for every TLS
+// handshake in this run, so a STARTTLS prelude (if any) is shared between
+// the version probes, the cipher suite probes, and the main connection.
+func checkSSL(w io.Writer, host, port string, timeout time.Duration, verbose bool, format string, dial dialFunc) (*Report, error) {
+	report := &Report{Host: host, Port: port}
+	text := format == "text"
+
+	if text {
+		fmt.Fprintf(w, "Checking SSL/TLS for %s:%s\n\n", host, port)
+	}
 
 	// Check supported protocols
-	fmt.Println("=== TLS Protocol Support ===")
-	checkTLSVersions(host, port, timeout)
-	fmt.Println()
+	if text {
+		fmt.Fprintln(w, "=== TLS Protocol Support ===")
+	}
+	supportedVersions, versionResults := checkTLSVersions(w, host, port, timeout, dial, format)
+	report.TLSVersions = versionResults
+	if text {
+		fmt.Fprintln(w)
+	}
+
+	// Enumerate and grade cipher suites for each supported version
+	if text {
+		fmt.Fprintln(w, "=== Cipher Suite Support ===")
+	}
+	report.CipherSuites = checkCipherSuites(w, host, port, timeout, supportedVersions, dial, format)
+	if text {
+		fmt.Fprintln(w)
+	}
 
 	// Connect using the highest available protocol
-	conn, err := tls.DialWithDialer(
-		&net.Dialer{Timeout: timeout},
-		"tcp",
-		fmt.Sprintf("%s:%s", host, port),
-		&tls.Config{
-			InsecureSkipVerify: true, // We do our own verification
-			ServerName:         host,
-		},
-	)
+	conn, err := dialTLS(dial, net.JoinHostPort(host, port), timeout, &tls.Config{
+		InsecureSkipVerify: true, // We do our own verification
+		ServerName:         host,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to connect: %v", err)
+		return report, fmt.Errorf("failed to connect: %v", err)
 	}
 	defer conn.Close()
 
 	// Get certificate chain
 	certs := conn.ConnectionState().PeerCertificates
 	if len(certs) == 0 {
-		return fmt.Errorf("no certificates found")
+		return report, fmt.Errorf("no certificates found")
 	}
 
-	fmt.Println("=== Certificate Chain ===")
-	// Check leaf certificate (server certificate)
-	fmt.Println("1. Server Certificate:")
+	if text {
+		fmt.Fprintln(w, "=== Certificate Chain ===")
+		fmt.Fprintln(w, "1. Server Certificate:")
+	}
 	leafCert := certs[0]
-	checkCertificate(leafCert, host, verbose)
+	leafResult, hostnameResult := checkCertificate(w, leafCert, host, "leaf", verbose, format)
+	report.Chain = append(report.Chain, leafResult)
+	report.HostnameVerification = hostnameResult
 
 	// Check intermediate certificates
 	intermediatesPool := x509.NewCertPool()
 	for i, cert := range certs[1:] {
+		role := "intermediate"
 		if i == len(certs)-2 { // Last certificate is usually the root
-			fmt.Printf("\n3. Root CA Certificate:\n")
-		} else {
-			fmt.Printf("\n2. Intermediate Certificate %d:\n", i+1)
+			role = "root"
+			if text {
+				fmt.Fprintf(w, "\n3. Root CA Certificate:\n")
+			}
+		} else if text {
+			fmt.Fprintf(w, "\n2. Intermediate Certificate %d:\n", i+1)
 		}
-		checkCertificate(cert, "", verbose)
+		certResult, _ := checkCertificate(w, cert, "", role, verbose, format)
+		report.Chain = append(report.Chain, certResult)
 		intermediatesPool.AddCert(cert)
 	}
 
 	// Verify certificate chain
-	fmt.Println("\n=== Certificate Chain Verification ===")
+	if text {
+		fmt.Fprintln(w, "\n=== Certificate Chain Verification ===")
+	}
 	roots := x509.NewCertPool()
 	// Try to use system root CA pool
 	systemRoots, err := x509.SystemCertPool()
@@ -104,79 +214,452 @@ func checkSSL(host, port string, timeout time.Duration, verbose bool) error {
 		Roots:         roots,
 	}
 
-	_, err = leafCert.Verify(opts)
+	_, verifyErr := leafCert.Verify(opts)
+	chainResult := VerificationResult{Passed: verifyErr == nil}
+	if verifyErr != nil {
+		chainResult.Error = verifyErr.Error()
+		if text {
+			fmt.Fprintln(w, "❌ Certificate chain verification FAILED")
+			fmt.Fprintf(w, "   Reason: %v\n", verifyErr)
+		}
+	} else if text {
+		fmt.Fprintln(w, "✅ Certificate chain verification PASSED")
+	}
+	report.ChainVerification = &chainResult
+
+	// Check OCSP revocation status of the leaf certificate
+	if text {
+		fmt.Fprintln(w, "\n=== OCSP Revocation Status ===")
+	}
+	var issuerCert *x509.Certificate
+	if len(certs) > 1 {
+		issuerCert = certs[1]
+	}
+	ocspResult, ocspResp := checkOCSP(w, leafCert, issuerCert, conn.ConnectionState().OCSPResponse, timeout, format)
+	report.OCSP = ocspResult
+
+	// Check CRL revocation status as a fallback/complement to OCSP
+	if text {
+		fmt.Fprintln(w, "\n=== CRL Revocation Status ===")
+	}
+	report.CRL = append(report.CRL, checkCRL(w, "Server Certificate", leafCert, issuerCert, timeout, format)...)
+	for i, cert := range certs[1:] {
+		if i == len(certs)-2 { // last certificate is usually the root, self-signed
+			break
+		}
+		report.CRL = append(report.CRL, checkCRL(w, fmt.Sprintf("Intermediate Certificate %d", i+1), cert, certs[i+2], timeout, format)...)
+	}
+
+	// Check Certificate Transparency SCTs for the leaf certificate
+	if text {
+		fmt.Fprintln(w, "\n=== Certificate Transparency (SCT) ===")
+	}
+	report.SCT = checkSCT(w, leafCert, conn.ConnectionState().SignedCertificateTimestamps, ocspResp, timeout, format)
+
+	return report, nil
+}
+
+// checkOCSP reports the OCSP revocation status of leafCert. It prefers a
+// stapled response from the TLS handshake and otherwise queries the
+// responder advertised in the certificate's AIA extension. It also returns
+// the parsed OCSP response, if any, so its extensions can be inspected
+// elsewhere (e.g. for embedded SCTs).
+func checkOCSP(w io.Writer, leafCert, issuerCert *x509.Certificate, stapled []byte, timeout time.Duration, format string) (*OCSPResult, *ocsp.Response) {
+	text := format == "text"
+
+	if issuerCert == nil {
+		if text {
+			fmt.Fprintln(w, "   ❓ Unknown: no issuer certificate available to verify OCSP response")
+		}
+		return &OCSPResult{Status: "unknown", Error: "no issuer certificate available to verify OCSP response"}, nil
+	}
+
+	if len(stapled) > 0 {
+		resp, err := ocsp.ParseResponse(stapled, issuerCert)
+		if err != nil {
+			if text {
+				fmt.Fprintf(w, "   ⚠️ Stapled OCSP response present but could not be parsed: %v\n", err)
+			}
+			return &OCSPResult{Status: "unknown", Stapled: true, Error: err.Error()}, nil
+		}
+		return printOCSPResponse(w, resp, true, format), resp
+	}
+
+	if len(leafCert.OCSPServer) == 0 {
+		if text {
+			fmt.Fprintln(w, "   ⚠️ No OCSP responder advertised (no AIA OCSP URL) and no stapled response")
+		}
+		return &OCSPResult{Status: "unknown", Error: "no OCSP responder advertised and no stapled response"}, nil
+	}
+
+	request, err := ocsp.CreateRequest(leafCert, issuerCert, nil)
+	if err != nil {
+		if text {
+			fmt.Fprintf(w, "   ⚠️ Failed to build OCSP request: %v\n", err)
+		}
+		return &OCSPResult{Status: "unknown", Error: err.Error()}, nil
+	}
+
+	responderURL := leafCert.OCSPServer[0]
+	client := &http.Client{Timeout: timeout}
+	httpResp, err := client.Post(responderURL, "application/ocsp-request", bytes.NewReader(request))
 	if err != nil {
-		fmt.Println("❌ Certificate chain verification FAILED")
-		fmt.Printf("   Reason: %v\n", err)
-	} else {
-		fmt.Println("✅ Certificate chain verification PASSED")
+		if text {
+			fmt.Fprintf(w, "   ⚠️ Failed to reach OCSP responder %s: %v\n", responderURL, err)
+		}
+		return &OCSPResult{Status: "unknown", Error: err.Error()}, nil
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		if text {
+			fmt.Fprintf(w, "   ⚠️ OCSP responder %s returned HTTP %d\n", responderURL, httpResp.StatusCode)
+		}
+		return &OCSPResult{Status: "unknown", Error: fmt.Sprintf("OCSP responder returned HTTP %d", httpResp.StatusCode)}, nil
+	}
+
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		if text {
+			fmt.Fprintf(w, "   ⚠️ Failed to read OCSP response body: %v\n", err)
+		}
+		return &OCSPResult{Status: "unknown", Error: err.Error()}, nil
+	}
+
+	resp, err := ocsp.ParseResponse(body, issuerCert)
+	if err != nil {
+		if text {
+			fmt.Fprintf(w, "   ⚠️ Failed to parse OCSP response: %v\n", err)
+		}
+		return &OCSPResult{Status: "unknown", Error: err.Error()}, nil
+	}
+
+	return printOCSPResponse(w, resp, false, format), resp
+}
+
+// printOCSPResponse prints (in text format) and returns a structured summary
+// of a parsed OCSP response.
+func printOCSPResponse(w io.Writer, resp *ocsp.Response, stapled bool, format string) *OCSPResult {
+	text := format == "text"
+	source := "queried"
+	if stapled {
+		source = "stapled"
+	}
+
+	result := &OCSPResult{Stapled: stapled}
+
+	switch resp.Status {
+	case ocsp.Good:
+		result.Status = "good"
+		if text {
+			fmt.Fprintf(w, "   ✅ Good (%s)\n", source)
+		}
+	case ocsp.Revoked:
+		result.Status = "revoked"
+		revokedAt := resp.RevokedAt
+		result.RevokedAt = &revokedAt
+		result.RevocationReason = formatRevocationReason(resp.RevocationReason)
+		if text {
+			fmt.Fprintf(w, "   ❌ Revoked (%s)\n", source)
+			fmt.Fprintf(w, "   Revoked at: %s\n", resp.RevokedAt.Format("2006-01-02 15:04:05 MST"))
+			fmt.Fprintf(w, "   Revocation reason: %s\n", result.RevocationReason)
+		}
+	default:
+		result.Status = "unknown"
+		if text {
+			fmt.Fprintf(w, "   ❓ Unknown (%s)\n", source)
+		}
 	}
 
-	return nil
+	thisUpdate := resp.ThisUpdate
+	result.ThisUpdate = &thisUpdate
+	if text {
+		fmt.Fprintf(w, "   This update: %s\n", resp.ThisUpdate.Format("2006-01-02 15:04:05 MST"))
+	}
+	if !resp.NextUpdate.IsZero() {
+		nextUpdate := resp.NextUpdate
+		result.NextUpdate = &nextUpdate
+		if text {
+			fmt.Fprintf(w, "   Next update: %s\n", resp.NextUpdate.Format("2006-01-02 15:04:05 MST"))
+		}
+		if time.Now().After(resp.NextUpdate) {
+			result.Stale = true
+			if text {
+				fmt.Fprintln(w, "   ⚠️ OCSP response is stale (past its NextUpdate time)")
+			}
+		}
+	}
+
+	return result
+}
+
+// formatRevocationReason converts an OCSP revocation reason code (RFC 5280
+// CRLReason) into a human-readable label.
+func formatRevocationReason(reason int) string {
+	switch reason {
+	case ocsp.Unspecified:
+		return "Unspecified"
+	case ocsp.KeyCompromise:
+		return "KeyCompromise"
+	case ocsp.CACompromise:
+		return "CACompromise"
+	case ocsp.AffiliationChanged:
+		return "AffiliationChanged"
+	case ocsp.Superseded:
+		return "Superseded"
+	case ocsp.CessationOfOperation:
+		return "CessationOfOperation"
+	case ocsp.CertificateHold:
+		return "CertificateHold"
+	case ocsp.RemoveFromCRL:
+		return "RemoveFromCRL"
+	case ocsp.PrivilegeWithdrawn:
+		return "PrivilegeWithdrawn"
+	case ocsp.AACompromise:
+		return "AACompromise"
+	default:
+		return fmt.Sprintf("Unknown(%d)", reason)
+	}
 }
 
-func checkCertificate(cert *x509.Certificate, hostname string, verbose bool) {
+// checkCRL reports the CRL-based revocation status of cert, checking every
+// URL in its CRLDistributionPoints extension. It complements the OCSP check
+// for servers/certs where OCSP is unreachable or unavailable.
+func checkCRL(w io.Writer, label string, cert, issuer *x509.Certificate, timeout time.Duration, format string) []CRLResult {
+	text := format == "text"
+	if text {
+		fmt.Fprintf(w, "%s:\n", label)
+	}
+
+	if len(cert.CRLDistributionPoints) == 0 {
+		if text {
+			fmt.Fprintln(w, "   ⚠️ No CRL distribution points in certificate")
+		}
+		return []CRLResult{{Label: label, Error: "no CRL distribution points in certificate"}}
+	}
+
+	var results []CRLResult
+
+	for _, url := range cert.CRLDistributionPoints {
+		crl, err := fetchCRL(url, timeout)
+		if err != nil {
+			if text {
+				fmt.Fprintf(w, "   ⚠️ Failed to fetch/parse CRL from %s: %v\n", url, err)
+			}
+			results = append(results, CRLResult{Label: label, URL: url, Error: err.Error()})
+			continue
+		}
+
+		if issuer != nil {
+			if err := crl.CheckSignatureFrom(issuer); err != nil {
+				if text {
+					fmt.Fprintf(w, "   ⚠️ CRL from %s has invalid signature: %v\n", url, err)
+				}
+				results = append(results, CRLResult{Label: label, URL: url, Error: fmt.Sprintf("invalid CRL signature: %v", err)})
+				continue
+			}
+		}
+
+		result := CRLResult{Label: label, URL: url}
+
+		if text {
+			fmt.Fprintf(w, "   CRL: %s\n", url)
+			fmt.Fprintf(w, "   This update: %s\n", crl.ThisUpdate.Format("2006-01-02 15:04:05 MST"))
+		}
+		thisUpdate := crl.ThisUpdate
+		result.ThisUpdate = &thisUpdate
+		if !crl.NextUpdate.IsZero() {
+			nextUpdate := crl.NextUpdate
+			result.NextUpdate = &nextUpdate
+			if text {
+				fmt.Fprintf(w, "   Next update: %s\n", crl.NextUpdate.Format("2006-01-02 15:04:05 MST"))
+			}
+			if time.Now().After(crl.NextUpdate) {
+				result.Stale = true
+				if text {
+					fmt.Fprintln(w, "   ⚠️ CRL is stale (past its NextUpdate time)")
+				}
+			}
+		}
+
+		for _, entry := range crl.RevokedCertificateEntries {
+			if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				result.Revoked = true
+				revokedAt := entry.RevocationTime
+				result.RevokedAt = &revokedAt
+				result.RevocationReason = formatRevocationReason(entry.ReasonCode)
+				if text {
+					fmt.Fprintln(w, "   ❌ Revoked")
+					fmt.Fprintf(w, "   Revoked at: %s\n", entry.RevocationTime.Format("2006-01-02 15:04:05 MST"))
+					fmt.Fprintf(w, "   Revocation reason: %s\n", result.RevocationReason)
+				}
+				break
+			}
+		}
+		if !result.Revoked && text {
+			fmt.Fprintln(w, "   ✅ Not listed as revoked")
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// fetchCRL downloads and parses the CRL at url, using crlCache to avoid
+// re-fetching the same distribution point more than once per process.
+func fetchCRL(url string, timeout time.Duration) (*x509.RevocationList, error) {
+	crlCache.mu.Lock()
+	if crl, ok := crlCache.entries[url]; ok {
+		crlCache.mu.Unlock()
+		return crl, nil
+	}
+	crlCache.mu.Unlock()
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download CRL: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CRL server returned HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CRL body: %v", err)
+	}
+
+	crl, err := x509.ParseRevocationList(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CRL: %v", err)
+	}
+
+	crlCache.mu.Lock()
+	crlCache.entries[url] = crl
+	crlCache.mu.Unlock()
+
+	return crl, nil
+}
+
+// checkCertificate prints (in text format) and returns a structured summary
+// of cert. hostnameResult is non-nil only when hostname is non-empty (i.e.
+// for the leaf certificate).
+func checkCertificate(w io.Writer, cert *x509.Certificate, hostname, role string, verbose bool, format string) (CertificateResult, *VerificationResult) {
+	text := format == "text"
+
 	// Check validity period
 	now := time.Now()
 	validFrom := cert.NotBefore
 	validTo := cert.NotAfter
 	daysLeft := int(validTo.Sub(now).Hours() / 24)
+	selfSigned := cert.Issuer.CommonName == cert.Subject.CommonName
 
-	fmt.Printf("   Subject: %s\n", cert.Subject.CommonName)
-	fmt.Printf("   Issuer: %s\n", cert.Issuer.CommonName)
-	fmt.Printf("   Valid from: %s to %s (%d days left)\n", 
-		validFrom.Format("2006-01-02"), 
-		validTo.Format("2006-01-02"), 
-		daysLeft)
-
-	// Check if certificate is valid
-	if now.Before(validFrom) {
-		fmt.Println("   ❌ Certificate is not yet valid")
-	} else if now.After(validTo) {
-		fmt.Println("   ❌ Certificate has expired")
-	} else {
-		fmt.Println("   ✅ Certificate date is valid")
+	result := CertificateResult{
+		Role:               role,
+		Subject:            cert.Subject.CommonName,
+		Issuer:             cert.Issuer.CommonName,
+		SerialNumber:       fmt.Sprintf("%X", cert.SerialNumber),
+		SANs:               cert.DNSNames,
+		KeyAlgorithm:       cert.PublicKeyAlgorithm.String(),
+		KeySizeBits:        publicKeySizeBits(cert),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		NotBefore:          validFrom,
+		NotAfter:           validTo,
+		DaysLeft:           daysLeft,
+		IsSelfSigned:       selfSigned,
 	}
 
-	// Check hostname match for leaf certificate
-	if hostname != "" {
-		if err := cert.VerifyHostname(hostname); err != nil {
-			fmt.Printf("   ❌ Hostname verification FAILED: %v\n", err)
+	if text {
+		fmt.Fprintf(w, "   Subject: %s\n", cert.Subject.CommonName)
+		fmt.Fprintf(w, "   Issuer: %s\n", cert.Issuer.CommonName)
+		fmt.Fprintf(w, "   Valid from: %s to %s (%d days left)\n",
+			validFrom.Format("2006-01-02"),
+			validTo.Format("2006-01-02"),
+			daysLeft)
+
+		// Check if certificate is valid
+		if now.Before(validFrom) {
+			fmt.Fprintln(w, "   ❌ Certificate is not yet valid")
+		} else if now.After(validTo) {
+			fmt.Fprintln(w, "   ❌ Certificate has expired")
 		} else {
-			fmt.Println("   ✅ Hostname verification PASSED")
+			fmt.Fprintln(w, "   ✅ Certificate date is valid")
 		}
 	}
 
-	// Check if certificate is self-signed
-	if cert.Issuer.CommonName == cert.Subject.CommonName {
-		fmt.Println("   ℹ️ Self-signed certificate detected")
+	// Check hostname match for leaf certificate
+	var hostnameResult *VerificationResult
+	if hostname != "" {
+		err := cert.VerifyHostname(hostname)
+		hostnameResult = &VerificationResult{Passed: err == nil}
+		if err != nil {
+			hostnameResult.Error = err.Error()
+			if text {
+				fmt.Fprintf(w, "   ❌ Hostname verification FAILED: %v\n", err)
+			}
+		} else if text {
+			fmt.Fprintln(w, "   ✅ Hostname verification PASSED")
+		}
 	}
 
-	// Print detailed certificate information if verbose mode is enabled
-	if verbose {
-		fmt.Println("   --- Detailed Certificate Information ---")
-		fmt.Printf("   Serial Number: %X\n", cert.SerialNumber)
-		fmt.Printf("   Signature Algorithm: %s\n", cert.SignatureAlgorithm)
-		fmt.Printf("   Public Key Algorithm: %s\n", cert.PublicKeyAlgorithm)
-		
-		// Print Subject Alternative Names
-		if len(cert.DNSNames) > 0 {
-			fmt.Printf("   DNS Names: %s\n", strings.Join(cert.DNSNames, ", "))
-		}
-		
-		// Print key usage if present
-		if cert.KeyUsage != 0 {
-			fmt.Printf("   Key Usage: %v\n", formatKeyUsage(cert.KeyUsage))
+	if text {
+		// Check if certificate is self-signed
+		if selfSigned {
+			fmt.Fprintln(w, "   ℹ️ Self-signed certificate detected")
 		}
-		
-		// Print extended key usage if present
-		if len(cert.ExtKeyUsage) > 0 {
-			fmt.Printf("   Extended Key Usage: %v\n", formatExtKeyUsage(cert.ExtKeyUsage))
+
+		// Print detailed certificate information if verbose mode is enabled
+		if verbose {
+			fmt.Fprintln(w, "   --- Detailed Certificate Information ---")
+			fmt.Fprintf(w, "   Serial Number: %X\n", cert.SerialNumber)
+			fmt.Fprintf(w, "   Signature Algorithm: %s\n", cert.SignatureAlgorithm)
+			fmt.Fprintf(w, "   Public Key Algorithm: %s\n", cert.PublicKeyAlgorithm)
+
+			// Print Subject Alternative Names
+			if len(cert.DNSNames) > 0 {
+				fmt.Fprintf(w, "   DNS Names: %s\n", strings.Join(cert.DNSNames, ", "))
+			}
+
+			// Print key usage if present
+			if cert.KeyUsage != 0 {
+				fmt.Fprintf(w, "   Key Usage: %v\n", formatKeyUsage(cert.KeyUsage))
+			}
+
+			// Print extended key usage if present
+			if len(cert.ExtKeyUsage) > 0 {
+				fmt.Fprintf(w, "   Extended Key Usage: %v\n", formatExtKeyUsage(cert.ExtKeyUsage))
+			}
 		}
 	}
+
+	return result, hostnameResult
 }
 
-func checkTLSVersions(host, port string, timeout time.Duration) {
+// publicKeySizeBits returns the modulus/curve size of cert's public key in
+// bits, or 0 if the key type isn't one we know how to measure.
+func publicKeySizeBits(cert *x509.Certificate) int {
+	switch pub := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return pub.N.BitLen()
+	case *ecdsa.PublicKey:
+		return pub.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return len(pub) * 8
+	default:
+		return 0
+	}
+}
+
+// checkTLSVersions probes each known protocol version and returns the ones
+// the server actually negotiated (for use by later checks such as cipher
+// suite enumeration) along with a structured per-version result.
+func checkTLSVersions(w io.Writer, host, port string, timeout time.Duration, dial dialFunc, format string) ([]uint16, []TLSVersionResult) {
+	text := format == "text"
+	addr := net.JoinHostPort(host, port)
+
 	versions := []uint16{
 		tls.VersionSSL30,
 		tls.VersionTLS10,
@@ -185,6 +668,9 @@ func checkTLSVersions(host, port string, timeout time.Duration) {
 		tls.VersionTLS13,
 	}
 
+	var supported []uint16
+	var results []TLSVersionResult
+
 	for _, version := range versions {
 		config := &tls.Config{
 			InsecureSkipVerify: true,
@@ -193,34 +679,171 @@ func checkTLSVersions(host, port string, timeout time.Duration) {
 			ServerName:         host,
 		}
 
-		conn, err := tls.DialWithDialer(
-			&net.Dialer{Timeout: timeout},
-			"tcp",
-			fmt.Sprintf("%s:%s", host, port),
-			config,
-		)
+		conn, err := dialTLS(dial, addr, timeout, config)
 
 		if err != nil {
-			fmt.Printf("   ❌ %s: Not supported\n", tlsVersions[version])
+			if text {
+				fmt.Fprintf(w, "   ❌ %s: Not supported\n", tlsVersions[version])
+			}
+			results = append(results, TLSVersionResult{Version: tlsVersions[version], Supported: false})
 		} else {
 			conn.Close()
 			negotiatedVersion := conn.ConnectionState().Version
 			if negotiatedVersion == version {
-				if version <= tls.VersionTLS11 {
-					fmt.Printf("   ⚠️ %s: Supported (DEPRECATED, SECURITY RISK)\n", tlsVersions[version])
-				} else {
-					fmt.Printf("   ✅ %s: Supported\n", tlsVersions[version])
+				supported = append(supported, version)
+				results = append(results, TLSVersionResult{Version: tlsVersions[version], Supported: true})
+				if text {
+					if version <= tls.VersionTLS11 {
+						fmt.Fprintf(w, "   ⚠️ %s: Supported (DEPRECATED, SECURITY RISK)\n", tlsVersions[version])
+					} else {
+						fmt.Fprintf(w, "   ✅ %s: Supported\n", tlsVersions[version])
+					}
 				}
 			} else {
-				fmt.Printf("   ❓ %s: Server negotiated different version\n", tlsVersions[version])
+				if text {
+					fmt.Fprintf(w, "   ❓ %s: Server negotiated different version\n", tlsVersions[version])
+				}
+				results = append(results, TLSVersionResult{Version: tlsVersions[version], Supported: false})
 			}
 		}
 	}
+
+	return supported, results
+}
+
+// checkCipherSuites probes, for each supported TLS version, which cipher
+// suites the server accepts and groups them into Strong/Weak/Insecure
+// buckets. TLS 1.3 suites cannot be filtered via Go's stdlib, so for that
+// version we report the suite negotiated on a normal connection instead.
+func checkCipherSuites(w io.Writer, host, port string, timeout time.Duration, versions []uint16, dial dialFunc, format string) []CipherSuiteResult {
+	text := format == "text"
+	addr := net.JoinHostPort(host, port)
+
+	var results []CipherSuiteResult
+
+	for _, version := range versions {
+		if text {
+			fmt.Fprintf(w, "%s:\n", tlsVersions[version])
+		}
+
+		if version == tls.VersionTLS13 {
+			config := &tls.Config{
+				InsecureSkipVerify: true,
+				MinVersion:         version,
+				MaxVersion:         version,
+				ServerName:         host,
+			}
+			conn, err := dialTLS(dial, addr, timeout, config)
+			if err != nil {
+				if text {
+					fmt.Fprintf(w, "   ❓ Could not connect to determine negotiated suite: %v\n", err)
+				}
+				results = append(results, CipherSuiteResult{Version: tlsVersions[version]})
+				continue
+			}
+			cs := conn.ConnectionState()
+			conn.Close()
+			if text {
+				fmt.Fprintf(w, "   Negotiated suite: %s\n", tls.CipherSuiteName(cs.CipherSuite))
+				fmt.Fprintln(w, "   (TLS 1.3 cipher suites cannot be individually probed via Go's stdlib)")
+			}
+			results = append(results, CipherSuiteResult{
+				Version:         tlsVersions[version],
+				NegotiatedSuite: tls.CipherSuiteName(cs.CipherSuite),
+			})
+			continue
+		}
+
+		allSuites := append(tls.CipherSuites(), tls.InsecureCipherSuites()...)
+		var strong, weak, insecure []string
+
+		for _, suite := range allSuites {
+			supportsVersion := false
+			for _, sv := range suite.SupportedVersions {
+				if sv == version {
+					supportsVersion = true
+					break
+				}
+			}
+			if !supportsVersion {
+				continue
+			}
+
+			config := &tls.Config{
+				InsecureSkipVerify: true,
+				MinVersion:         version,
+				MaxVersion:         version,
+				CipherSuites:       []uint16{suite.ID},
+				ServerName:         host,
+			}
+			conn, err := dialTLS(dial, addr, timeout, config)
+			if err != nil {
+				continue
+			}
+			conn.Close()
+
+			switch gradeCipherSuite(suite.Name) {
+			case "Insecure":
+				insecure = append(insecure, suite.Name)
+			case "Weak":
+				weak = append(weak, suite.Name)
+			default:
+				strong = append(strong, suite.Name)
+			}
+		}
+
+		if text {
+			if len(strong) == 0 && len(weak) == 0 && len(insecure) == 0 {
+				fmt.Fprintln(w, "   (no cipher suites accepted)")
+			}
+			if len(strong) > 0 {
+				fmt.Fprintf(w, "   ✅ Strong: %s\n", strings.Join(strong, ", "))
+			}
+			if len(weak) > 0 {
+				fmt.Fprintf(w, "   ⚠️ Weak: %s\n", strings.Join(weak, ", "))
+			}
+			if len(insecure) > 0 {
+				fmt.Fprintf(w, "   ❌ Insecure: %s\n", strings.Join(insecure, ", "))
+			}
+		}
+
+		results = append(results, CipherSuiteResult{
+			Version:  tlsVersions[version],
+			Strong:   strong,
+			Weak:     weak,
+			Insecure: insecure,
+		})
+	}
+
+	if text {
+		fmt.Fprintln(w, "   (negotiated curve/group not reported: crypto/tls's ConnectionState does not expose it)")
+	}
+
+	return results
+}
+
+// gradeCipherSuite buckets a cipher suite name into "Insecure", "Weak", or
+// "Strong" based on its mode and key exchange.
+func gradeCipherSuite(name string) string {
+	switch {
+	case strings.Contains(name, "RC4"),
+		strings.Contains(name, "3DES"),
+		strings.Contains(name, "NULL"),
+		strings.Contains(name, "EXPORT"),
+		strings.Contains(name, "DES_CBC"):
+		return "Insecure"
+	case strings.Contains(name, "CBC"),
+		strings.HasPrefix(name, "TLS_RSA_WITH"):
+		// CBC-mode suites and plain RSA key exchange (no forward secrecy)
+		return "Weak"
+	default:
+		return "Strong"
+	}
 }
 
 func formatKeyUsage(usage x509.KeyUsage) string {
 	var usages []string
-	
+
 	if usage&x509.KeyUsageDigitalSignature != 0 {
 		usages = append(usages, "DigitalSignature")
 	}
@@ -248,13 +871,13 @@ func formatKeyUsage(usage x509.KeyUsage) string {
 	if usage&x509.KeyUsageDecipherOnly != 0 {
 		usages = append(usages, "DecipherOnly")
 	}
-	
+
 	return strings.Join(usages, ", ")
 }
 
 func formatExtKeyUsage(usage []x509.ExtKeyUsage) string {
 	var usages []string
-	
+
 	for _, u := range usage {
 		switch u {
 		case x509.ExtKeyUsageAny:
@@ -281,6 +904,6 @@ func formatExtKeyUsage(usage []x509.ExtKeyUsage) string {
 			usages = append(usages, fmt.Sprintf("Unknown(%d)", u))
 		}
 	}
-	
+
 	return strings.Join(usages, ", ")
 }
@@ -0,0 +1,112 @@
+package main
+
+import "time"
+
+// Report is the structured result of a single sslcheck run, used to
+// serialize -format json output. Every field mirrors a section of the
+// human-readable report produced by checkSSL.
+type Report struct {
+	Host                 string              `json:"host"`
+	Port                 string              `json:"port"`
+	Error                string              `json:"error,omitempty"`
+	TLSVersions          []TLSVersionResult  `json:"tls_versions,omitempty"`
+	CipherSuites         []CipherSuiteResult `json:"cipher_suites,omitempty"`
+	Chain                []CertificateResult `json:"chain,omitempty"`
+	ChainVerification    *VerificationResult `json:"chain_verification,omitempty"`
+	HostnameVerification *VerificationResult `json:"hostname_verification,omitempty"`
+	OCSP                 *OCSPResult         `json:"ocsp,omitempty"`
+	CRL                  []CRLResult         `json:"crl,omitempty"`
+	SCT                  *SCTSummary         `json:"sct,omitempty"`
+}
+
+// TLSVersionResult reports whether a single TLS protocol version was
+// negotiated successfully against the target.
+type TLSVersionResult struct {
+	Version   string `json:"version"`
+	Supported bool   `json:"supported"`
+}
+
+// CipherSuiteResult reports the cipher suites accepted by the server for a
+// single TLS version, bucketed by grade. NegotiatedSuite is only populated
+// for TLS 1.3, where individual suites can't be probed.
+type CipherSuiteResult struct {
+	Version         string   `json:"version"`
+	Strong          []string `json:"strong,omitempty"`
+	Weak            []string `json:"weak,omitempty"`
+	Insecure        []string `json:"insecure,omitempty"`
+	NegotiatedSuite string   `json:"negotiated_suite,omitempty"`
+}
+
+// CertificateResult is the JSON-serializable view of one certificate in the
+// chain returned by the server.
+type CertificateResult struct {
+	Role               string    `json:"role"` // leaf, intermediate, or root
+	Subject            string    `json:"subject"`
+	Issuer             string    `json:"issuer"`
+	SerialNumber       string    `json:"serial_number"`
+	SANs               []string  `json:"sans,omitempty"`
+	KeyAlgorithm       string    `json:"key_algorithm"`
+	KeySizeBits        int       `json:"key_size_bits,omitempty"`
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	NotBefore          time.Time `json:"not_before"`
+	NotAfter           time.Time `json:"not_after"`
+	DaysLeft           int       `json:"days_left"`
+	IsSelfSigned       bool      `json:"is_self_signed"`
+}
+
+// VerificationResult is a generic pass/fail result with an optional reason,
+// used for both chain and hostname verification.
+type VerificationResult struct {
+	Passed bool   `json:"passed"`
+	Error  string `json:"error,omitempty"`
+}
+
+// OCSPResult reports the outcome of the OCSP revocation check for the leaf
+// certificate. Status is one of "good", "revoked", or "unknown".
+type OCSPResult struct {
+	Status           string     `json:"status"`
+	Stapled          bool       `json:"stapled"`
+	ThisUpdate       *time.Time `json:"this_update,omitempty"`
+	NextUpdate       *time.Time `json:"next_update,omitempty"`
+	Stale            bool       `json:"stale,omitempty"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	RevocationReason string     `json:"revocation_reason,omitempty"`
+	Error            string     `json:"error,omitempty"`
+}
+
+// CRLResult reports the outcome of checking one certificate against one CRL
+// distribution point.
+type CRLResult struct {
+	Label            string     `json:"label"`
+	URL              string     `json:"url,omitempty"`
+	Revoked          bool       `json:"revoked"`
+	ThisUpdate       *time.Time `json:"this_update,omitempty"`
+	NextUpdate       *time.Time `json:"next_update,omitempty"`
+	Stale            bool       `json:"stale,omitempty"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	RevocationReason string     `json:"revocation_reason,omitempty"`
+	Error            string     `json:"error,omitempty"`
+}
+
+// SCTSummary reports the Signed Certificate Timestamps found for the leaf
+// certificate across all three delivery mechanisms, and whether their count
+// meets Chrome's CT policy (at least two SCTs from qualified logs).
+type SCTSummary struct {
+	Count        int        `json:"count"`
+	MeetsPolicy  bool       `json:"meets_policy"`
+	PolicyMinSCT int        `json:"policy_min_sct"`
+	Entries      []SCTEntry `json:"entries,omitempty"`
+}
+
+// SCTEntry is the JSON-serializable view of one parsed Signed Certificate
+// Timestamp.
+type SCTEntry struct {
+	Source         string    `json:"source"` // tls, ocsp, or x509
+	LogID          string    `json:"log_id"`
+	LogOperator    string    `json:"log_operator,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+	HashAlgorithm  string    `json:"hash_algorithm"`
+	SignatureAlgo  string    `json:"signature_algorithm"`
+	SignatureValid *bool     `json:"signature_valid,omitempty"`
+	Error          string    `json:"error,omitempty"`
+}
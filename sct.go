@@ -0,0 +1,422 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// chromeCTPolicyMinSCTs is the minimum number of SCTs from qualified logs
+// Chrome's CT policy requires for a certificate to be trusted. The real
+// policy scales with certificate lifetime (2 for short-lived certs, more for
+// longer-lived ones); sslcheck reports against the common baseline of 2.
+const chromeCTPolicyMinSCTs = 2
+
+// oidSCTListX509Extension is the X.509 extension OID under which a CA
+// embeds a SignedCertificateTimestampList directly in an issued certificate.
+var oidSCTListX509Extension = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+// oidSCTListOCSPExtension is the OCSP single response extension OID under
+// which a responder can deliver a SignedCertificateTimestampList.
+var oidSCTListOCSPExtension = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 5}
+
+// ctLogListURL is Google's published, continuously updated list of
+// qualified/usable CT logs and their public keys. CT logs rotate
+// (shard/retire) often enough that a list frozen at build time would go
+// stale, so sslcheck fetches and caches this for the process lifetime, the
+// same strategy checkCRL already uses for CRLs (see crlCache).
+const ctLogListURL = "https://www.gstatic.com/ct/log_list/v3/log_list.json"
+
+// ctLogInfo is a bundled CT log's operator name and public key, keyed by
+// log ID in ctLogListCache.
+type ctLogInfo struct {
+	Operator  string
+	PublicKey interface{} // *ecdsa.PublicKey or *rsa.PublicKey
+}
+
+// ctLogListCache memoizes the fetched and parsed Google CT log list, keyed
+// by hex-encoded log ID, for the lifetime of the process. A failed fetch is
+// cached too (as an empty map) so a single offline/unreachable run doesn't
+// retry the request once per SCT.
+var ctLogListCache = struct {
+	mu      sync.Mutex
+	fetched bool
+	byLogID map[string]ctLogInfo
+}{}
+
+// ctLogListEntry and ctLogListOperator mirror the subset of Google's
+// log_list.json schema (https://www.gstatic.com/ct/log_list/v3/log_list.json)
+// sslcheck needs: each operator's published logs, by ID and public key.
+type ctLogListEntry struct {
+	LogID string `json:"log_id"`
+	Key   string `json:"key"`
+}
+
+type ctLogListOperator struct {
+	Name string           `json:"name"`
+	Logs []ctLogListEntry `json:"logs"`
+}
+
+type ctLogListDocument struct {
+	Operators []ctLogListOperator `json:"operators"`
+}
+
+// fetchCTLogList returns the bundled CT log list, fetching and parsing it
+// from ctLogListURL on first use. On any failure (offline, timeout, bad
+// response) it returns an empty map, so lookups degrade to "unknown log"
+// rather than failing the whole SCT check.
+func fetchCTLogList(timeout time.Duration) map[string]ctLogInfo {
+	ctLogListCache.mu.Lock()
+	defer ctLogListCache.mu.Unlock()
+
+	if ctLogListCache.fetched {
+		return ctLogListCache.byLogID
+	}
+	ctLogListCache.fetched = true
+	ctLogListCache.byLogID = make(map[string]ctLogInfo)
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(ctLogListURL)
+	if err != nil {
+		return ctLogListCache.byLogID
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ctLogListCache.byLogID
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ctLogListCache.byLogID
+	}
+
+	var doc ctLogListDocument
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return ctLogListCache.byLogID
+	}
+
+	for _, op := range doc.Operators {
+		for _, log := range op.Logs {
+			idBytes, err := base64.StdEncoding.DecodeString(log.LogID)
+			if err != nil || len(idBytes) != 32 {
+				continue
+			}
+			keyBytes, err := base64.StdEncoding.DecodeString(log.Key)
+			if err != nil {
+				continue
+			}
+			pub, err := x509.ParsePKIXPublicKey(keyBytes)
+			if err != nil {
+				continue
+			}
+			ctLogListCache.byLogID[hex.EncodeToString(idBytes)] = ctLogInfo{Operator: op.Name, PublicKey: pub}
+		}
+	}
+
+	return ctLogListCache.byLogID
+}
+
+// ctSCT is a parsed RFC 6962 SignedCertificateTimestamp.
+type ctSCT struct {
+	Version       uint8
+	LogID         [32]byte
+	Timestamp     uint64 // milliseconds since the Unix epoch
+	Extensions    []byte
+	HashAlgorithm uint8
+	SigAlgorithm  uint8
+	Signature     []byte
+}
+
+// parseSCTList parses an RFC 6962 SignedCertificateTimestampList: a 2-byte
+// total length followed by 2-byte-length-prefixed serialized SCTs.
+func parseSCTList(data []byte) ([][]byte, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("SCT list too short")
+	}
+	total := int(binary.BigEndian.Uint16(data[0:2]))
+	data = data[2:]
+	if total != len(data) {
+		return nil, fmt.Errorf("SCT list length mismatch: header says %d, have %d", total, len(data))
+	}
+
+	var scts [][]byte
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return nil, fmt.Errorf("truncated SCT entry length")
+		}
+		n := int(binary.BigEndian.Uint16(data[0:2]))
+		data = data[2:]
+		if len(data) < n {
+			return nil, fmt.Errorf("truncated SCT entry")
+		}
+		scts = append(scts, data[:n])
+		data = data[n:]
+	}
+	return scts, nil
+}
+
+// parseSCT parses a single RFC 6962 SignedCertificateTimestamp.
+func parseSCT(raw []byte) (*ctSCT, error) {
+	if len(raw) < 1+32+8+2 {
+		return nil, fmt.Errorf("SCT too short")
+	}
+	sct := &ctSCT{Version: raw[0]}
+	copy(sct.LogID[:], raw[1:33])
+	sct.Timestamp = binary.BigEndian.Uint64(raw[33:41])
+
+	extLen := int(binary.BigEndian.Uint16(raw[41:43]))
+	offset := 43
+	if len(raw) < offset+extLen {
+		return nil, fmt.Errorf("truncated SCT extensions")
+	}
+	sct.Extensions = raw[offset : offset+extLen]
+	offset += extLen
+
+	if len(raw) < offset+4 {
+		return nil, fmt.Errorf("truncated SCT signature header")
+	}
+	sct.HashAlgorithm = raw[offset]
+	sct.SigAlgorithm = raw[offset+1]
+	sigLen := int(binary.BigEndian.Uint16(raw[offset+2 : offset+4]))
+	offset += 4
+	if len(raw) < offset+sigLen {
+		return nil, fmt.Errorf("truncated SCT signature")
+	}
+	sct.Signature = raw[offset : offset+sigLen]
+
+	return sct, nil
+}
+
+// sctListFromExtension extracts a SignedCertificateTimestampList from a raw
+// extension value, which wraps the list in a DER OCTET STRING.
+func sctListFromExtension(value []byte) ([][]byte, error) {
+	var wrapped []byte
+	if _, err := asn1.Unmarshal(value, &wrapped); err != nil {
+		return nil, fmt.Errorf("unwrapping SCT list OCTET STRING: %v", err)
+	}
+	return parseSCTList(wrapped)
+}
+
+// findExtension returns the value of the first extension in exts matching
+// oid, or nil if not present.
+func findExtension(exts []pkix.Extension, oid asn1.ObjectIdentifier) []byte {
+	for _, ext := range exts {
+		if ext.Id.Equal(oid) {
+			return ext.Value
+		}
+	}
+	return nil
+}
+
+// sctHashAlgorithmName and sctSigAlgorithmName map the single-byte
+// TLS HashAlgorithm/SignatureAlgorithm enum values (RFC 5246 section 7.4.1.4.1)
+// used inside an SCT's digitally-signed struct to their names.
+func sctHashAlgorithmName(id uint8) string {
+	switch id {
+	case 0:
+		return "none"
+	case 1:
+		return "md5"
+	case 2:
+		return "sha1"
+	case 3:
+		return "sha224"
+	case 4:
+		return "sha256"
+	case 5:
+		return "sha384"
+	case 6:
+		return "sha512"
+	default:
+		return fmt.Sprintf("unknown(%d)", id)
+	}
+}
+
+func sctSigAlgorithmName(id uint8) string {
+	switch id {
+	case 0:
+		return "anonymous"
+	case 1:
+		return "rsa"
+	case 2:
+		return "dsa"
+	case 3:
+		return "ecdsa"
+	default:
+		return fmt.Sprintf("unknown(%d)", id)
+	}
+}
+
+// verifySCTOverCertificate verifies an SCT's signature over the
+// "x509_entry" form of the RFC 6962 digitally-signed struct: the full DER
+// certificate as submitted to the log. This is the form used for SCTs
+// delivered out-of-band (via the TLS extension or a stapled OCSP response);
+// SCTs embedded in the certificate itself use the "precert_entry" form
+// instead, which requires reconstructing the issuer's pre-certificate (the
+// TBSCertificate with the SCT extension removed) and isn't implemented here.
+func verifySCTOverCertificate(sct *ctSCT, cert *x509.Certificate, pub interface{}) error {
+	signed := make([]byte, 0, 1+1+8+2+3+len(cert.Raw)+2+len(sct.Extensions))
+	signed = append(signed, sct.Version)
+	signed = append(signed, 0) // signature_type = certificate_timestamp
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], sct.Timestamp)
+	signed = append(signed, ts[:]...)
+	signed = append(signed, 0, 0) // entry_type = x509_entry
+	certLen := len(cert.Raw)
+	signed = append(signed, byte(certLen>>16), byte(certLen>>8), byte(certLen))
+	signed = append(signed, cert.Raw...)
+	extLen := len(sct.Extensions)
+	signed = append(signed, byte(extLen>>8), byte(extLen))
+	signed = append(signed, sct.Extensions...)
+
+	digest := sha256.Sum256(signed)
+
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], sct.Signature) {
+			return fmt.Errorf("signature does not verify against log public key")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sct.Signature); err != nil {
+			return fmt.Errorf("signature does not verify against log public key: %v", err)
+		}
+	default:
+		return fmt.Errorf("unsupported log public key type %T", pub)
+	}
+	return nil
+}
+
+// describeSCT builds the reporting entry for one parsed SCT, looking up its
+// log operator in the bundled CT log list and, when the SCT was delivered
+// out-of-band, verifying its signature against that log's public key.
+func describeSCT(source string, raw []byte, cert *x509.Certificate, knownLogs map[string]ctLogInfo) SCTEntry {
+	entry := SCTEntry{Source: source}
+
+	sct, err := parseSCT(raw)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+
+	entry.LogID = hex.EncodeToString(sct.LogID[:])
+	entry.Timestamp = time.UnixMilli(int64(sct.Timestamp)).UTC()
+	entry.HashAlgorithm = sctHashAlgorithmName(sct.HashAlgorithm)
+	entry.SignatureAlgo = sctSigAlgorithmName(sct.SigAlgorithm)
+
+	log, known := knownLogs[entry.LogID]
+	if !known {
+		entry.Error = "unknown log: ID not in Google's published CT log list"
+		return entry
+	}
+	entry.LogOperator = log.Operator
+
+	if source == "x509" {
+		entry.Error = "embedded SCT signature not verified: requires precertificate reconstruction"
+		return entry
+	}
+
+	valid := verifySCTOverCertificate(sct, cert, log.PublicKey) == nil
+	entry.SignatureValid = &valid
+
+	return entry
+}
+
+// checkSCT collects and reports Signed Certificate Timestamps for leafCert
+// from all three delivery mechanisms: the TLS SignedCertificateTimestamps
+// extension, the stapled/queried OCSP response, and the certificate's own
+// embedded SCT extension. It reports the total count against Chrome's CT
+// policy minimum.
+func checkSCT(w io.Writer, leafCert *x509.Certificate, tlsSCTs [][]byte, ocspResp *ocsp.Response, timeout time.Duration, format string) *SCTSummary {
+	text := format == "text"
+	summary := &SCTSummary{PolicyMinSCT: chromeCTPolicyMinSCTs}
+	knownLogs := fetchCTLogList(timeout)
+
+	for _, raw := range tlsSCTs {
+		summary.Entries = append(summary.Entries, describeSCT("tls", raw, leafCert, knownLogs))
+	}
+
+	if ocspResp != nil {
+		if value := findExtension(ocspResp.Extensions, oidSCTListOCSPExtension); value != nil {
+			if scts, err := sctListFromExtension(value); err != nil {
+				if text {
+					fmt.Fprintf(w, "   ⚠️ Failed to parse SCT list in OCSP response: %v\n", err)
+				}
+			} else {
+				for _, raw := range scts {
+					summary.Entries = append(summary.Entries, describeSCT("ocsp", raw, leafCert, knownLogs))
+				}
+			}
+		}
+	}
+
+	if value := findExtension(leafCert.Extensions, oidSCTListX509Extension); value != nil {
+		if scts, err := sctListFromExtension(value); err != nil {
+			if text {
+				fmt.Fprintf(w, "   ⚠️ Failed to parse embedded SCT list: %v\n", err)
+			}
+		} else {
+			for _, raw := range scts {
+				summary.Entries = append(summary.Entries, describeSCT("x509", raw, leafCert, knownLogs))
+			}
+		}
+	}
+
+	// Only SCTs that parsed cleanly and came from a log on Google's published
+	// list count toward Chrome's policy minimum; malformed or unqualified
+	// entries still show up in Entries, just not here.
+	for _, e := range summary.Entries {
+		if e.LogOperator != "" {
+			summary.Count++
+		}
+	}
+	summary.MeetsPolicy = summary.Count >= chromeCTPolicyMinSCTs
+
+	if text {
+		for _, e := range summary.Entries {
+			label := e.LogOperator
+			if label == "" {
+				label = "unknown log"
+			}
+			if e.LogID == "" {
+				// parseSCT failed, so there's no log ID or timestamp to show.
+				fmt.Fprintf(w, "   • [%s] %s\n", e.Source, label)
+			} else {
+				logID := e.LogID
+				if len(logID) > 16 {
+					logID = logID[:16]
+				}
+				fmt.Fprintf(w, "   • [%s] %s (log ID %s..., %s)\n", e.Source, label, logID, e.Timestamp.Format("2006-01-02 15:04:05 MST"))
+			}
+			switch {
+			case e.SignatureValid != nil && *e.SignatureValid:
+				fmt.Fprintln(w, "     ✅ Signature verified")
+			case e.SignatureValid != nil:
+				fmt.Fprintln(w, "     ❌ Signature verification FAILED")
+			case e.Error != "":
+				fmt.Fprintf(w, "     ℹ️ %s\n", e.Error)
+			}
+		}
+		if summary.MeetsPolicy {
+			fmt.Fprintf(w, "   ✅ %d SCT(s) found, meets Chrome's policy minimum of %d\n", summary.Count, summary.PolicyMinSCT)
+		} else {
+			fmt.Fprintf(w, "   ❌ Only %d SCT(s) found, below Chrome's policy minimum of %d\n", summary.Count, summary.PolicyMinSCT)
+		}
+	}
+
+	return summary
+}
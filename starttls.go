@@ -0,0 +1,407 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/asn1"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// dialFunc establishes a plaintext connection to addr that is ready to be
+// handed to tls.Client for the TLS handshake. For plain TLS this is just a
+// TCP dial; for STARTTLS protocols it also performs the plaintext prelude
+// that negotiates the upgrade.
+type dialFunc func(addr string, timeout time.Duration) (net.Conn, error)
+
+// starttlsDefaultPorts maps a -starttls protocol name to the port sslcheck
+// should use when the user didn't pass -port explicitly.
+var starttlsDefaultPorts = map[string]string{
+	"smtp":     "25",
+	"imap":     "143",
+	"pop3":     "110",
+	"ftp":      "21",
+	"ldap":     "389",
+	"postgres": "5432",
+}
+
+// starttlsDialers maps a -starttls protocol name to the dialFunc that
+// performs its plaintext-to-TLS upgrade prelude.
+var starttlsDialers = map[string]dialFunc{
+	"smtp":     smtpDial,
+	"imap":     imapDial,
+	"pop3":     pop3Dial,
+	"ftp":      ftpDial,
+	"ldap":     ldapDial,
+	"postgres": postgresDial,
+}
+
+// plainDial is the dialFunc used when no -starttls protocol is given: a
+// plain TCP connection, handed straight to tls.Client.
+func plainDial(addr string, timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout("tcp", addr, timeout)
+}
+
+// dialTLS dials addr via dial, then performs the TLS handshake over the
+// resulting connection using config. It mirrors tls.DialWithDialer but
+// allows a STARTTLS prelude to run first.
+func dialTLS(dial dialFunc, addr string, timeout time.Duration, config *tls.Config) (*tls.Conn, error) {
+	conn, err := dial(addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConn := tls.Client(conn, config)
+	tlsConn.SetDeadline(time.Now().Add(timeout))
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	tlsConn.SetDeadline(time.Time{})
+
+	return tlsConn, nil
+}
+
+// smtpDial performs the SMTP STARTTLS prelude: read the banner, EHLO, then
+// STARTTLS, expecting a 220 response before handing back the raw conn.
+func smtpDial(addr string, timeout time.Duration) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	reader := bufio.NewReader(conn)
+	if _, err := readReplyCode(reader); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("reading SMTP banner: %v", err)
+	}
+
+	if _, err := fmt.Fprintf(conn, "EHLO sslcheck\r\n"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if _, err := readReplyCode(reader); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("EHLO failed: %v", err)
+	}
+
+	if _, err := fmt.Fprintf(conn, "STARTTLS\r\n"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	code, err := readReplyCode(reader)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("STARTTLS failed: %v", err)
+	}
+	if code != "220" {
+		conn.Close()
+		return nil, fmt.Errorf("STARTTLS rejected with code %s", code)
+	}
+
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// readReplyCode reads a (possibly multi-line) SMTP- or FTP-style reply and
+// returns its three-digit status code. Both protocols use the same
+// continuation convention: "NNN-" for a line with more to follow, "NNN " for
+// the final line.
+func readReplyCode(reader *bufio.Reader) (string, error) {
+	var code string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		if len(line) < 4 {
+			return "", fmt.Errorf("malformed reply: %q", line)
+		}
+		code = line[:3]
+		if line[3] == ' ' { // '-' means more lines follow
+			return code, nil
+		}
+	}
+}
+
+// imapDial performs the IMAP STARTTLS prelude: read the greeting, then tag
+// a STARTTLS command and wait for the matching tagged OK.
+func imapDial(addr string, timeout time.Duration) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	reader := bufio.NewReader(conn)
+	if _, err := reader.ReadString('\n'); err != nil { // greeting
+		conn.Close()
+		return nil, fmt.Errorf("reading IMAP greeting: %v", err)
+	}
+
+	if _, err := fmt.Fprintf(conn, "a1 STARTTLS\r\n"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("reading STARTTLS response: %v", err)
+		}
+		if !strings.HasPrefix(line, "a1 ") {
+			continue
+		}
+		if strings.HasPrefix(line, "a1 OK") {
+			break
+		}
+		conn.Close()
+		return nil, fmt.Errorf("STARTTLS rejected: %s", strings.TrimSpace(line))
+	}
+
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// pop3Dial performs the POP3 STLS prelude: read the greeting, send STLS,
+// expect +OK.
+func pop3Dial(addr string, timeout time.Duration) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	reader := bufio.NewReader(conn)
+	if _, err := reader.ReadString('\n'); err != nil { // greeting
+		conn.Close()
+		return nil, fmt.Errorf("reading POP3 greeting: %v", err)
+	}
+
+	if _, err := fmt.Fprintf(conn, "STLS\r\n"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("reading STLS response: %v", err)
+	}
+	if !strings.HasPrefix(line, "+OK") {
+		conn.Close()
+		return nil, fmt.Errorf("STLS rejected: %s", strings.TrimSpace(line))
+	}
+
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// ftpDial performs the FTP AUTH TLS prelude: read the welcome, send
+// AUTH TLS, expect 234.
+func ftpDial(addr string, timeout time.Duration) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	reader := bufio.NewReader(conn)
+	if _, err := readReplyCode(reader); err != nil { // welcome, often multi-line (220-...)
+		conn.Close()
+		return nil, fmt.Errorf("reading FTP welcome: %v", err)
+	}
+
+	if _, err := fmt.Fprintf(conn, "AUTH TLS\r\n"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	code, err := readReplyCode(reader)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("reading AUTH TLS response: %v", err)
+	}
+	if code != "234" {
+		conn.Close()
+		return nil, fmt.Errorf("AUTH TLS rejected with code %s", code)
+	}
+
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// ldapExtendedOIDStartTLS is the LDAP StartTLS extended operation OID.
+const ldapExtendedOIDStartTLS = "1.3.6.1.4.1.1466.20037"
+
+// ldapExtendedRequest is the [APPLICATION 23] ExtendedRequest PDU, carrying
+// just the requestName (the StartTLS OID, encoded as [0] like an OCTET
+// STRING per RFC 4511).
+type ldapExtendedRequest struct {
+	RequestName []byte `asn1:"tag:0"`
+}
+
+// ldapMessage is the top-level LDAPMessage envelope wrapping the extended
+// request.
+type ldapMessage struct {
+	MessageID       int
+	ExtendedRequest ldapExtendedRequest `asn1:"tag:23,application"`
+}
+
+// ldapDial performs the LDAP StartTLS extended operation prelude.
+func ldapDial(addr string, timeout time.Duration) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	request, err := asn1.Marshal(ldapMessage{
+		MessageID:       1,
+		ExtendedRequest: ldapExtendedRequest{RequestName: []byte(ldapExtendedOIDStartTLS)},
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("encoding LDAP StartTLS request: %v", err)
+	}
+
+	if _, err := conn.Write(request); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	buf := make([]byte, 256)
+	n, err := conn.Read(buf)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("reading LDAP StartTLS response: %v", err)
+	}
+	if n == 0 {
+		conn.Close()
+		return nil, fmt.Errorf("empty LDAP StartTLS response")
+	}
+
+	resultCode, err := ldapExtendedResponseResultCode(buf[:n])
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("parsing LDAP StartTLS response: %v", err)
+	}
+	if resultCode != 0 {
+		conn.Close()
+		return nil, fmt.Errorf("StartTLS rejected with LDAP resultCode %d", resultCode)
+	}
+
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// ldapTLV reads one BER tag-length-value element from data (definite
+// lengths only, short- or long-form), returning the tag byte, its value
+// bytes, and whatever follows it.
+func ldapTLV(data []byte) (tag byte, value, rest []byte, err error) {
+	if len(data) < 2 {
+		return 0, nil, nil, fmt.Errorf("truncated BER element")
+	}
+	tag = data[0]
+	length := int(data[1])
+	offset := 2
+	if length&0x80 != 0 {
+		numBytes := length &^ 0x80
+		if numBytes == 0 || len(data) < offset+numBytes {
+			return 0, nil, nil, fmt.Errorf("unsupported or truncated BER length")
+		}
+		length = 0
+		for _, b := range data[offset : offset+numBytes] {
+			length = length<<8 | int(b)
+		}
+		offset += numBytes
+	}
+	if len(data) < offset+length {
+		return 0, nil, nil, fmt.Errorf("truncated BER value")
+	}
+	return tag, data[offset : offset+length], data[offset+length:], nil
+}
+
+// ldapExtendedResponseResultCode extracts the resultCode from a raw
+// LDAPMessage wrapping an ExtendedResponse (RFC 4511 section 4.1.9/4.12): a
+// SEQUENCE containing the messageID INTEGER followed by an
+// [APPLICATION 24] ExtendedResponse whose first element is the
+// resultCode ENUMERATED. This is a minimal, targeted walk rather than a
+// general BER decoder, just enough to tell success (0) from failure.
+func ldapExtendedResponseResultCode(data []byte) (int, error) {
+	_, message, _, err := ldapTLV(data) // outer SEQUENCE (LDAPMessage)
+	if err != nil {
+		return 0, fmt.Errorf("LDAPMessage: %v", err)
+	}
+
+	_, _, rest, err := ldapTLV(message) // messageID INTEGER
+	if err != nil {
+		return 0, fmt.Errorf("messageID: %v", err)
+	}
+
+	tag, response, _, err := ldapTLV(rest) // [APPLICATION 24] ExtendedResponse
+	if err != nil {
+		return 0, fmt.Errorf("ExtendedResponse: %v", err)
+	}
+	const extendedResponseTag = 0x40 | 0x20 | 24 // APPLICATION, constructed, tag 24
+	if tag != extendedResponseTag {
+		return 0, fmt.Errorf("unexpected protocolOp tag %#x", tag)
+	}
+
+	resultTag, resultValue, _, err := ldapTLV(response) // resultCode ENUMERATED
+	if err != nil {
+		return 0, fmt.Errorf("resultCode: %v", err)
+	}
+	const enumeratedTag = 0x0a
+	if resultTag != enumeratedTag {
+		return 0, fmt.Errorf("unexpected resultCode tag %#x", resultTag)
+	}
+
+	code := 0
+	for _, b := range resultValue {
+		code = code<<8 | int(b)
+	}
+	return code, nil
+}
+
+// postgresSSLRequestCode is the fixed SSLRequest code from the PostgreSQL
+// frontend/backend protocol (section 52.2.2 of the Postgres docs).
+const postgresSSLRequestCode = 80877103
+
+// postgresDial performs the PostgreSQL SSLRequest prelude: send the 8-byte
+// SSLRequest message and expect a single 'S' byte back.
+func postgresDial(addr string, timeout time.Duration) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	request := make([]byte, 8)
+	request[0], request[1], request[2], request[3] = 0, 0, 0, 8
+	code := uint32(postgresSSLRequestCode)
+	request[4] = byte(code >> 24)
+	request[5] = byte(code >> 16)
+	request[6] = byte(code >> 8)
+	request[7] = byte(code)
+
+	if _, err := conn.Write(request); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reply := make([]byte, 1)
+	if _, err := conn.Read(reply); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("reading SSLRequest response: %v", err)
+	}
+	if reply[0] != 'S' {
+		conn.Close()
+		return nil, fmt.Errorf("server does not support SSL (replied %q)", reply[0])
+	}
+
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}